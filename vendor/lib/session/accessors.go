@@ -0,0 +1,205 @@
+package session
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+)
+
+// timeType special-cases time.Time in deepCopyValue: it's an immutable value
+// type safe to copy field-for-field as-is, but its fields (wall, ext, loc)
+// are unexported, so the generic reflect.Struct case below can't touch them
+// and would otherwise silently hand back a zero time.Time.
+var timeType = reflect.TypeOf(time.Time{})
+
+// GetString returns the value stored under key as a string.
+func (session *Session) GetString(key string) (string, error) {
+	v := session.Get(key)
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("session: value for %q is %T, not string", key, v)
+	}
+	return s, nil
+}
+
+// GetInt returns the value stored under key as an int.
+func (session *Session) GetInt(key string) (int, error) {
+	v := session.Get(key)
+	i, ok := v.(int)
+	if !ok {
+		return 0, fmt.Errorf("session: value for %q is %T, not int", key, v)
+	}
+	return i, nil
+}
+
+// GetInt64 returns the value stored under key as an int64.
+func (session *Session) GetInt64(key string) (int64, error) {
+	v := session.Get(key)
+	i, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("session: value for %q is %T, not int64", key, v)
+	}
+	return i, nil
+}
+
+// GetFloat64 returns the value stored under key as a float64.
+func (session *Session) GetFloat64(key string) (float64, error) {
+	v := session.Get(key)
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("session: value for %q is %T, not float64", key, v)
+	}
+	return f, nil
+}
+
+// GetBool returns the value stored under key as a bool.
+func (session *Session) GetBool(key string) (bool, error) {
+	v := session.Get(key)
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("session: value for %q is %T, not bool", key, v)
+	}
+	return b, nil
+}
+
+// GetAll returns a defensive shallow copy of the session's data, so callers
+// can range over it without holding the session's lock or racing a
+// concurrent Set. Keys stored with SetImmutable are deep-copied, same as Get.
+func (session *Session) GetAll() map[string]interface{} {
+	session.lock.RLock()
+	defer session.lock.RUnlock()
+
+	all := make(map[string]interface{}, len(session.data))
+	for k, v := range session.data {
+		if session.immutable[k] {
+			v = deepCopy(v)
+		}
+		all[k] = v
+	}
+	return all
+}
+
+// VisitAll calls fn for every key/value pair in the session, holding the
+// session's own lock for the duration so it is safe to call from a handler
+// that might race a concurrent Set on the same session. Keys stored with
+// SetImmutable are deep-copied, same as Get.
+func (session *Session) VisitAll(fn func(key string, value interface{})) {
+	session.lock.RLock()
+	defer session.lock.RUnlock()
+
+	for k, v := range session.data {
+		if session.immutable[k] {
+			v = deepCopy(v)
+		}
+		fn(k, v)
+	}
+}
+
+// SetImmutable stores a deep copy of value under key, so that later Get calls
+// return data the caller cannot mutate through a shared slice, map, or
+// pointer. Use this for values a handler will hand off to other goroutines,
+// or when the caller doesn't fully trust its own code not to mutate what it
+// reads back.
+//
+// The copy is best-effort for struct types with unexported fields (other
+// than time.Time): those are logged and returned shallow rather than copied,
+// since reflection can't set an unexported field without risking a silent,
+// zeroed-out copy.
+func (session *Session) SetImmutable(key string, value interface{}) {
+	copied := deepCopy(value)
+
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.data[key] = copied
+	session.immutable[key] = true
+}
+
+// deepCopy recursively copies the kinds that could otherwise alias shared
+// state (maps, slices, arrays, pointers, interfaces, and structs whose fields
+// are all exported); every other kind is already copied by value when
+// assigned, so it's returned as-is.
+func deepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return deepCopyValue(reflect.ValueOf(v)).Interface()
+}
+
+func deepCopyValue(val reflect.Value) reflect.Value {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return val
+		}
+		dst := reflect.New(val.Type().Elem())
+		dst.Elem().Set(deepCopyValue(val.Elem()))
+		return dst
+	case reflect.Interface:
+		if val.IsNil() {
+			return val
+		}
+		dst := reflect.New(val.Type()).Elem()
+		dst.Set(deepCopyValue(val.Elem()))
+		return dst
+	case reflect.Map:
+		if val.IsNil() {
+			return val
+		}
+		dst := reflect.MakeMapWithSize(val.Type(), val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(deepCopyValue(iter.Key()), deepCopyValue(iter.Value()))
+		}
+		return dst
+	case reflect.Slice:
+		if val.IsNil() {
+			return val
+		}
+		dst := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		for i := 0; i < val.Len(); i++ {
+			dst.Index(i).Set(deepCopyValue(val.Index(i)))
+		}
+		return dst
+	case reflect.Array:
+		dst := reflect.New(val.Type()).Elem()
+		for i := 0; i < val.Len(); i++ {
+			dst.Index(i).Set(deepCopyValue(val.Index(i)))
+		}
+		return dst
+	case reflect.Struct:
+		if val.Type() == timeType {
+			return val
+		}
+		if hasUnexportedField(val.Type()) {
+			// This type needs its own case above (as time.Time has) before it
+			// can be copied field-for-field. Handler-supplied session values
+			// are arbitrary types we don't control, so a type we can't fully
+			// copy must not crash the caller - fall back to handing back val
+			// itself. It's still a fresh struct value at the top level (the
+			// caller's original isn't aliased), just not deep-copied below
+			// any unexported field, so mutations reachable only through one
+			// of those fields can still be shared.
+			log.Printf("session: deepCopy cannot fully copy %s (has an unexported field); falling back to a shallow copy", val.Type())
+			return val
+		}
+		dst := reflect.New(val.Type()).Elem()
+		for i := 0; i < val.NumField(); i++ {
+			dst.Field(i).Set(deepCopyValue(val.Field(i)))
+		}
+		return dst
+	default:
+		return val
+	}
+}
+
+// hasUnexportedField reports whether t, a struct type, has any field
+// deepCopyValue cannot reflect.Value.Set on.
+func hasUnexportedField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSetImmutableIsolatesCaller(t *testing.T) {
+	session := NewSession("test-immutable")
+
+	original := []string{"a", "b"}
+	session.SetImmutable("tags", original)
+
+	original[0] = "mutated"
+
+	got := session.Get("tags").([]string)
+	if got[0] != "a" {
+		t.Fatalf("SetImmutable leaked caller's mutation: got %v", got)
+	}
+
+	got[1] = "also-mutated"
+	if again := session.Get("tags").([]string); again[1] != "b" {
+		t.Fatalf("SetImmutable leaked mutation via returned value: got %v", again)
+	}
+}
+
+func TestVisitAllAndGetAll(t *testing.T) {
+	session := NewSession("test-visit")
+	session.Set("a", 1)
+	session.Set("b", 2)
+
+	seen := make(map[string]interface{})
+	session.VisitAll(func(key string, value interface{}) {
+		seen[key] = value
+	})
+	if len(seen) != 2 || seen["a"] != 1 || seen["b"] != 2 {
+		t.Fatalf("VisitAll saw %v, want a=1 b=2", seen)
+	}
+
+	all := session.GetAll()
+	all["a"] = 99
+	if v, _ := session.GetInt("a"); v != 1 {
+		t.Fatalf("GetAll copy was not defensive: session value changed to %d", v)
+	}
+}
+
+func TestSetImmutablePreservesTime(t *testing.T) {
+	session := NewSession("test-immutable-time")
+
+	now := time.Now()
+	session.SetImmutable("issued", now)
+
+	got, ok := session.Get("issued").(time.Time)
+	if !ok {
+		t.Fatalf("Get(%q) = %v, want time.Time", "issued", session.Get("issued"))
+	}
+	if !got.Equal(now) {
+		t.Fatalf("deepCopy of time.Time = %v, want %v", got, now)
+	}
+}
+
+func TestSetImmutableDoesNotPanicOnUnexportedFields(t *testing.T) {
+	session := NewSession("test-immutable-unexported")
+
+	re := regexp.MustCompile("ab+c")
+
+	session.SetImmutable("pattern", re)
+
+	got, ok := session.Get("pattern").(*regexp.Regexp)
+	if !ok {
+		t.Fatalf("Get(%q) = %v, want *regexp.Regexp", "pattern", session.Get("pattern"))
+	}
+	if got.String() != re.String() {
+		t.Fatalf("shallow-copied *regexp.Regexp = %q, want %q", got.String(), re.String())
+	}
+}
@@ -0,0 +1,125 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// CookieStore keeps no server-side state at all: a session's data is
+// serialized, compressed, HMAC-signed and AES-encrypted by a
+// secureCookieCodec into the session ID itself, so the "ID" handed back to
+// the Manager *is* the cookie value. This trades server memory for a larger
+// cookie and is a good fit for stateless/horizontally-scaled deployments.
+type CookieStore struct {
+	codec *secureCookieCodec
+
+	lock   sync.RWMutex
+	maxAge time.Duration
+}
+
+// NewCookieStore creates a Provider that stores session data entirely on the
+// client. Cookies are signed and encrypted with hashKeys[0]/blockKeys[0]; to
+// rotate keys, prepend a new pair to both slices and deploy - cookies signed
+// with older pairs keep verifying for as long as those pairs stay in the
+// slices, since every pair is tried in order on decode, and are re-signed
+// with the new pair whenever SessionSave or SessionRegenerate reissues
+// them. It panics if hashKeys and blockKeys are empty, mismatched in
+// length, or contain a block key of invalid AES size.
+func NewCookieStore(hashKeys, blockKeys [][]byte) *CookieStore {
+	codec, err := newSecureCookieCodec(hashKeys, blockKeys)
+	if err != nil {
+		panic(err)
+	}
+	return &CookieStore{codec: codec, maxAge: defaultManagerConfig.Lifespan}
+}
+
+func (store *CookieStore) SessionInit(sid string) (*Session, error) {
+	return store.reissue(NewSession(sid))
+}
+
+func (store *CookieStore) SessionRead(sid string) (*Session, error) {
+	record, err := store.decode(sid)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		id:        sid,
+		data:      record.Data,
+		immutable: make(map[string]bool),
+		flashes:   record.Flashes,
+		lastUsed:  record.LastUsed,
+	}, nil
+}
+
+func (store *CookieStore) SessionExist(sid string) bool {
+	_, err := store.decode(sid)
+	return err == nil
+}
+
+// SessionDestroy is a no-op: there is no server-side state to remove. Ending
+// the session is the caller's responsibility via clearing the client cookie.
+func (store *CookieStore) SessionDestroy(sid string) error {
+	return nil
+}
+
+// SessionSave re-encodes session's current data into a fresh ID. Since a
+// CookieStore's "ID" is the encoded blob itself, the caller must write the
+// returned session's new ID back into the client's cookie - the Manager does
+// this automatically when SessionSave is reached through Manager.Save.
+func (store *CookieStore) SessionSave(session *Session) (*Session, error) {
+	return store.reissue(session)
+}
+
+// SessionRegenerate re-encodes session's current, in-memory data under a new
+// ID. newSid is unused: a CookieStore's ID always *is* its encoded data, so
+// reissue produces the real new ID regardless of what's suggested here.
+func (store *CookieStore) SessionRegenerate(session *Session, newSid string) (*Session, error) {
+	return store.reissue(session)
+}
+
+// SessionGC doesn't sweep anything - there is nothing server-side to sweep -
+// but it does capture maxLifetime as the age a cookie is allowed to reach
+// before SessionRead and SessionExist start rejecting it.
+func (store *CookieStore) SessionGC(maxLifetime time.Duration) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.maxAge = maxLifetime
+}
+
+// SessionAll cannot be known for a stateless provider.
+func (store *CookieStore) SessionAll() int {
+	return -1
+}
+
+// reissue re-encodes session's data into its id, which is what the Manager
+// will write back into the client's cookie. session.lock is held across the
+// encode itself, not just the field reads beforehand, since record.Data and
+// record.Flashes alias session's live maps - releasing the lock any earlier
+// would let a concurrent Set race with the encoder reading them.
+func (store *CookieStore) reissue(session *Session) (*Session, error) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	now := time.Now()
+	record := secureCookieRecord{
+		Data:     session.data,
+		Flashes:  session.flashes,
+		LastUsed: now,
+		IssuedAt: now.Unix(),
+	}
+
+	encoded, err := store.codec.encode(record)
+	if err != nil {
+		return nil, err
+	}
+	session.id = encoded
+	session.lastUsed = now
+	return session, nil
+}
+
+func (store *CookieStore) decode(sid string) (secureCookieRecord, error) {
+	store.lock.RLock()
+	maxAge := store.maxAge
+	store.lock.RUnlock()
+	return store.codec.decode(sid, maxAge)
+}
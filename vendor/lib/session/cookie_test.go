@@ -0,0 +1,97 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCookieStoreSaveAndRead(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	store := NewCookieStore(hashKeys, blockKeys)
+
+	session, err := store.SessionInit("unused-seed")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	session.Set("user", "ada")
+
+	saved, err := store.SessionSave(session)
+	if err != nil {
+		t.Fatalf("SessionSave() error = %v", err)
+	}
+
+	read, err := store.SessionRead(saved.ID())
+	if err != nil {
+		t.Fatalf("SessionRead() error = %v", err)
+	}
+	if got, _ := read.GetString("user"); got != "ada" {
+		t.Fatalf("SessionRead() user = %q, want %q", got, "ada")
+	}
+}
+
+func TestCookieStoreSessionRegenerateChangesID(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	store := NewCookieStore(hashKeys, blockKeys)
+
+	session, err := store.SessionInit("unused-seed")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	session.Set("role", "admin")
+	oldID := session.ID()
+
+	// newSid is deliberately ignored by CookieStore - its real ID is always
+	// the freshly encoded blob - so passing an arbitrary string must not leak
+	// into the result.
+	regenerated, err := store.SessionRegenerate(session, "ignored")
+	if err != nil {
+		t.Fatalf("SessionRegenerate() error = %v", err)
+	}
+	if regenerated.ID() == oldID || regenerated.ID() == "ignored" {
+		t.Fatalf("SessionRegenerate() ID = %q, want a freshly encoded blob", regenerated.ID())
+	}
+
+	read, err := store.SessionRead(regenerated.ID())
+	if err != nil {
+		t.Fatalf("SessionRead() error = %v", err)
+	}
+	if got, _ := read.GetString("role"); got != "admin" {
+		t.Fatalf("SessionRead() role = %q, want %q", got, "admin")
+	}
+}
+
+func TestCookieStoreSessionExistRejectsTampering(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	store := NewCookieStore(hashKeys, blockKeys)
+
+	session, err := store.SessionInit("unused-seed")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+
+	if !store.SessionExist(session.ID()) {
+		t.Fatal("SessionExist() = false for a cookie this store just issued")
+	}
+	if store.SessionExist(session.ID() + "tampered") {
+		t.Fatal("SessionExist() = true for a tampered cookie value")
+	}
+}
+
+func TestCookieStoreSessionGCRejectsStaleCookies(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	store := NewCookieStore(hashKeys, blockKeys)
+
+	session, err := store.SessionInit("unused-seed")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+
+	// Shrinking maxAge to a few milliseconds and letting the cookie outlive it
+	// makes a cookie issued moments ago read back as expired.
+	store.SessionGC(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := store.SessionRead(session.ID()); err == nil {
+		t.Fatal("SessionRead() should reject a cookie older than the GC'd maxAge")
+	}
+}
@@ -0,0 +1,180 @@
+package session
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileProvider stores each session as a gob-encoded file under savePath, named
+// after the session ID. It is suitable for single-instance deployments that
+// need sessions to survive a process restart.
+type fileProvider struct {
+	lock     sync.Mutex
+	savePath string
+}
+
+// NewFileProvider creates a Provider that persists one gob-encoded file per
+// session under savePath. savePath must already exist and be writable.
+func NewFileProvider(savePath string) Provider {
+	return &fileProvider{savePath: savePath}
+}
+
+type fileSessionRecord struct {
+	Data     map[string]interface{}
+	Flashes  map[string]interface{}
+	LastUsed time.Time
+}
+
+func (provider *fileProvider) path(sid string) string {
+	return filepath.Join(provider.savePath, sid+".sess")
+}
+
+func (provider *fileProvider) SessionInit(sid string) (*Session, error) {
+	session := NewSession(sid)
+	if err := provider.write(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (provider *fileProvider) SessionRead(sid string) (*Session, error) {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	file, err := os.Open(provider.path(sid))
+	if err != nil {
+		return nil, fmt.Errorf("session: no session stored under id %q: %w", sid, err)
+	}
+	defer file.Close()
+
+	var record fileSessionRecord
+	if err := gob.NewDecoder(file).Decode(&record); err != nil {
+		return nil, fmt.Errorf("session: decoding session %q: %w", sid, err)
+	}
+
+	return &Session{
+		id:        sid,
+		data:      record.Data,
+		immutable: make(map[string]bool),
+		flashes:   record.Flashes,
+		lastUsed:  record.LastUsed,
+	}, nil
+}
+
+// SessionSave rewrites session's file with its current data, so mutations a
+// handler made through Set, SetFlash, etc. survive to the next request. Every
+// SessionRead hands back a detached copy decoded from disk, so without this
+// call those mutations are invisible outside the request that made them.
+func (provider *fileProvider) SessionSave(session *Session) (*Session, error) {
+	if err := provider.write(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (provider *fileProvider) SessionExist(sid string) bool {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	_, err := os.Stat(provider.path(sid))
+	return err == nil
+}
+
+func (provider *fileProvider) SessionDestroy(sid string) error {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	err := os.Remove(provider.path(sid))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (provider *fileProvider) SessionRegenerate(session *Session, newSid string) (*Session, error) {
+	oldSid := session.ID()
+
+	// Hold session's own lock for the full swap, so a concurrent ID() or
+	// Get() on the same live *Session can never observe a half-updated id.
+	session.lock.Lock()
+	session.id = newSid
+	session.lastUsed = time.Now()
+	session.lock.Unlock()
+
+	if err := provider.write(session); err != nil {
+		return nil, err
+	}
+
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	if err := os.Remove(provider.path(oldSid)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (provider *fileProvider) SessionGC(maxLifetime time.Duration) {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	entries, err := os.ReadDir(provider.savePath)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Now().After(info.ModTime().Add(maxLifetime)) {
+			os.Remove(filepath.Join(provider.savePath, entry.Name()))
+		}
+	}
+}
+
+func (provider *fileProvider) SessionAll() int {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	entries, err := os.ReadDir(provider.savePath)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			count++
+		}
+	}
+	return count
+}
+
+// write gob-encodes session to its file, overwriting any existing contents.
+func (provider *fileProvider) write(session *Session) error {
+	sid := session.ID()
+
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	file, err := os.Create(provider.path(sid))
+	if err != nil {
+		return fmt.Errorf("session: creating session file for %q: %w", sid, err)
+	}
+	defer file.Close()
+
+	session.lock.RLock()
+	record := fileSessionRecord{Data: session.data, Flashes: session.flashes, LastUsed: session.lastUsed}
+	session.lock.RUnlock()
+
+	if err := gob.NewEncoder(file).Encode(record); err != nil {
+		return fmt.Errorf("session: encoding session %q: %w", sid, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileProviderSaveAndRead(t *testing.T) {
+	provider := NewFileProvider(t.TempDir())
+
+	session, err := provider.SessionInit("test-file")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	session.Set("name", "ada")
+
+	if _, err := provider.SessionSave(session); err != nil {
+		t.Fatalf("SessionSave() error = %v", err)
+	}
+
+	read, err := provider.SessionRead("test-file")
+	if err != nil {
+		t.Fatalf("SessionRead() error = %v", err)
+	}
+	if got, _ := read.GetString("name"); got != "ada" {
+		t.Fatalf("SessionRead() name = %q, want %q", got, "ada")
+	}
+}
+
+func TestFileProviderSessionExistAndDestroy(t *testing.T) {
+	provider := NewFileProvider(t.TempDir())
+
+	if provider.SessionExist("missing") {
+		t.Fatal("SessionExist() = true for a session never created")
+	}
+
+	if _, err := provider.SessionInit("test-destroy"); err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	if !provider.SessionExist("test-destroy") {
+		t.Fatal("SessionExist() = false right after SessionInit")
+	}
+
+	if err := provider.SessionDestroy("test-destroy"); err != nil {
+		t.Fatalf("SessionDestroy() error = %v", err)
+	}
+	if provider.SessionExist("test-destroy") {
+		t.Fatal("SessionExist() = true after SessionDestroy")
+	}
+
+	// Destroying an already-missing session is not an error.
+	if err := provider.SessionDestroy("test-destroy"); err != nil {
+		t.Fatalf("SessionDestroy() on missing session error = %v", err)
+	}
+}
+
+func TestFileProviderSessionRegenerate(t *testing.T) {
+	provider := NewFileProvider(t.TempDir())
+
+	session, err := provider.SessionInit("old-id")
+	if err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	session.Set("cart", 3)
+
+	regenerated, err := provider.SessionRegenerate(session, "new-id")
+	if err != nil {
+		t.Fatalf("SessionRegenerate() error = %v", err)
+	}
+	if regenerated.ID() != "new-id" {
+		t.Fatalf("SessionRegenerate() ID = %q, want %q", regenerated.ID(), "new-id")
+	}
+	if provider.SessionExist("old-id") {
+		t.Fatal("old session file should be removed after SessionRegenerate")
+	}
+
+	read, err := provider.SessionRead("new-id")
+	if err != nil {
+		t.Fatalf("SessionRead(new-id) error = %v", err)
+	}
+	if got, _ := read.GetInt("cart"); got != 3 {
+		t.Fatalf("SessionRead(new-id) cart = %d, want 3", got)
+	}
+}
+
+func TestFileProviderSessionGC(t *testing.T) {
+	provider := NewFileProvider(t.TempDir())
+
+	if _, err := provider.SessionInit("expired"); err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+	if _, err := provider.SessionInit("fresh"); err != nil {
+		t.Fatalf("SessionInit() error = %v", err)
+	}
+
+	// SessionGC keys off each file's mtime, not the in-memory Session, so the
+	// only way to simulate an old session here is to age the file itself.
+	fp := provider.(*fileProvider)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(fp.path("expired"), old, old); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+
+	provider.SessionGC(time.Hour)
+
+	if provider.SessionExist("expired") {
+		t.Fatal("SessionGC should have removed the expired session")
+	}
+	if !provider.SessionExist("fresh") {
+		t.Fatal("SessionGC should not have removed the fresh session")
+	}
+}
@@ -0,0 +1,54 @@
+package session
+
+// SetFlash stashes a one-shot value under key. Flashes are read exactly once:
+// the first GetFlash or GetFlashes call for a key removes it, so handlers can
+// carry a message ("Login failed", "Profile saved") across a redirect without
+// it lingering in persistent session state.
+func (session *Session) SetFlash(key string, v interface{}) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if session.flashes == nil {
+		session.flashes = make(map[string]interface{})
+	}
+	session.flashes[key] = v
+}
+
+// GetFlash returns the flash stored under key and deletes it. It returns nil
+// if no flash is stored under key.
+func (session *Session) GetFlash(key string) interface{} {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	v, ok := session.flashes[key]
+	if !ok {
+		return nil
+	}
+	delete(session.flashes, key)
+	return v
+}
+
+// HasFlash reports whether any flash messages are pending.
+func (session *Session) HasFlash() bool {
+	session.lock.RLock()
+	defer session.lock.RUnlock()
+	return len(session.flashes) > 0
+}
+
+// GetFlashes returns every pending flash message and clears all of them,
+// exactly as repeated GetFlash calls would.
+func (session *Session) GetFlashes() map[string]interface{} {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if len(session.flashes) == 0 {
+		return map[string]interface{}{}
+	}
+	flashes := session.flashes
+	session.flashes = make(map[string]interface{})
+	return flashes
+}
+
+// ClearFlashes discards every pending flash message without returning them.
+func (session *Session) ClearFlashes() {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.flashes = make(map[string]interface{})
+}
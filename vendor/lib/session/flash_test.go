@@ -0,0 +1,100 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlashReadOnce(t *testing.T) {
+	session := NewSession("test-flash")
+
+	if session.HasFlash() {
+		t.Fatal("new session should have no flashes")
+	}
+
+	session.SetFlash("notice", "Profile saved")
+
+	if !session.HasFlash() {
+		t.Fatal("expected a pending flash")
+	}
+	if got := session.GetFlash("notice"); got != "Profile saved" {
+		t.Fatalf("GetFlash() = %v, want %q", got, "Profile saved")
+	}
+
+	// A second read must not see the same flash again.
+	if got := session.GetFlash("notice"); got != nil {
+		t.Fatalf("GetFlash() on second read = %v, want nil", got)
+	}
+	if session.HasFlash() {
+		t.Fatal("flash should have been cleared after being read")
+	}
+}
+
+func TestGetFlashesClearsAll(t *testing.T) {
+	session := NewSession("test-flashes")
+	session.SetFlash("a", 1)
+	session.SetFlash("b", 2)
+
+	flashes := session.GetFlashes()
+	if len(flashes) != 2 {
+		t.Fatalf("GetFlashes() returned %d entries, want 2", len(flashes))
+	}
+	if session.HasFlash() {
+		t.Fatal("GetFlashes should have cleared all pending flashes")
+	}
+}
+
+func TestFlashSurvivesRegenerate(t *testing.T) {
+	manager, err := NewManager("memory", nil)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	session := manager.Start(rec, req)
+	session.SetFlash("notice", "Login failed")
+	oldID := session.ID()
+
+	// Regenerate takes the same *Session the handler already has, e.g. right
+	// after checking credentials - it doesn't need the request's cookie at
+	// all. The Manager mutates the session in place and hands back the same
+	// pointer under a new ID, so regenerated and session are intentionally
+	// the same object here; what must change is the ID itself.
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec2 := httptest.NewRecorder()
+
+	regenerated := manager.Regenerate(rec2, req2, session)
+	if regenerated.ID() == oldID {
+		t.Fatal("Regenerate should issue a new session ID")
+	}
+	if got := regenerated.GetFlash("notice"); got != "Login failed" {
+		t.Fatalf("GetFlash() after Regenerate = %v, want %q", got, "Login failed")
+	}
+}
+
+func TestFlashClearedOnEnd(t *testing.T) {
+	manager, err := NewManager("memory", nil)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	session := manager.Start(rec, req)
+	session.SetFlash("notice", "Logged out")
+
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req2.AddCookie(c)
+	}
+	rec2 := httptest.NewRecorder()
+	manager.End(rec2, req2)
+
+	if manager.provider.SessionExist(session.ID()) {
+		t.Fatal("session should no longer exist after End")
+	}
+}
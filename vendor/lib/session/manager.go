@@ -3,102 +3,228 @@ package session
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sync"
 	"time"
 )
 
-// Manager is a singleton that starts and ends sessions
+// Manager starts and ends sessions, storing them through a Provider.
 type Manager struct {
-	lock     sync.Mutex
-	sessions map[string]*Session
+	provider Provider
+	config   ManagerConfig
 }
 
-// Time until session is destroyed
-const lifespan = 2 * time.Hour
+// ManagerConfig controls how a Manager names, times out and secures its
+// cookies. Provider-specific settings (e.g. a file provider's save path, or a
+// cookie provider's secret) are configured on the Provider itself before it
+// is registered.
+type ManagerConfig struct {
+	// CookieName is the name of the cookie used to track the session ID.
+	CookieName string
+	// Lifespan is how long a session may go unused before it is collected.
+	Lifespan time.Duration
+	// GCFrequency is how often expired sessions are swept.
+	GCFrequency time.Duration
+	// Secure sets the cookie's Secure flag. nil falls back to the default
+	// (false), same as every other zero field in a partial ManagerConfig.
+	Secure *bool
+	// HttpOnly sets the cookie's HttpOnly flag. nil falls back to the
+	// default (true): unlike a zero time.Duration or empty string, Go's
+	// zero value for bool (false) is indistinguishable from "the caller
+	// explicitly asked for this off", so a plain bool field here would
+	// silently turn off HttpOnly for any caller who didn't set it.
+	HttpOnly *bool
+}
 
-// Cookie name to save the session ID with
-const cookieName = "go-webapp-sessionid"
+// defaultManagerConfig matches the behavior this package had before it
+// supported pluggable providers.
+var defaultManagerConfig = ManagerConfig{
+	CookieName:  "go-webapp-sessionid",
+	Lifespan:    2 * time.Hour,
+	GCFrequency: 10 * time.Minute,
+	Secure:      boolPtr(false),
+	HttpOnly:    boolPtr(true),
+}
 
-// Frequency the sessions are cleared
-const cleanFrequency = 10 * time.Minute
+func boolPtr(b bool) *bool { return &b }
 
-// Singleton storage
-var sessionManager = Manager{
-	sessions: make(map[string]*Session),
-}
+// NewManager creates a Manager backed by the Provider registered under
+// providerName. cfg may be nil, in which case defaultManagerConfig is used;
+// any zero fields in cfg also fall back to the matching default.
+func NewManager(providerName string, cfg *ManagerConfig) (*Manager, error) {
+	p, err := provider(providerName)
+	if err != nil {
+		return nil, err
+	}
 
-// Run a seperate thread to periodically clean the unused sessions
-func init() {
-	go cleanSessions()
+	resolved := defaultManagerConfig
+	if cfg != nil {
+		if cfg.CookieName != "" {
+			resolved.CookieName = cfg.CookieName
+		}
+		if cfg.Lifespan != 0 {
+			resolved.Lifespan = cfg.Lifespan
+		}
+		if cfg.GCFrequency != 0 {
+			resolved.GCFrequency = cfg.GCFrequency
+		}
+		if cfg.Secure != nil {
+			resolved.Secure = cfg.Secure
+		}
+		if cfg.HttpOnly != nil {
+			resolved.HttpOnly = cfg.HttpOnly
+		}
+	}
+
+	manager := &Manager{provider: p, config: resolved}
+	// Prime the provider with the resolved Lifespan synchronously: providers
+	// like CookieStore validate against whatever maxLifetime they were last
+	// told via SessionGC, which otherwise defaults to defaultManagerConfig's
+	// value until the background sweep's first tick (up to GCFrequency
+	// later) - sessions issued in that window would be checked against the
+	// wrong lifespan.
+	manager.provider.SessionGC(resolved.Lifespan)
+	go manager.cleanSessions()
+	return manager, nil
 }
 
-// GetManager returns the session manager, which can be used to start and end sessions
+// sessionManager is the default Manager, backed by the in-memory provider,
+// kept for callers that have not migrated to NewManager. It is built lazily
+// by GetManager rather than at package-init time: the "memory" provider
+// registers itself via its own init() in memory.go, and package-level var
+// initializers all run before any init() does, so constructing sessionManager
+// as a plain var here would race NewManager("memory", ...) against that
+// registration and could find no provider registered yet.
+var (
+	sessionManager     *Manager
+	sessionManagerOnce sync.Once
+)
+
+// GetManager returns the default in-memory-backed session manager, building
+// it on first use.
+//
+// Deprecated: construct a Manager with NewManager so the storage provider
+// can be chosen explicitly.
 func GetManager() *Manager {
-	return &sessionManager
+	sessionManagerOnce.Do(func() {
+		manager, err := NewManager("memory", nil)
+		if err != nil {
+			log.Fatalf("session: failed to build default manager: %v", err)
+		}
+		sessionManager = manager
+	})
+	return sessionManager
 }
 
 // Start is a method that returns the session associated with the current user. If there is
 // not yet a session, create a new one.
 func (manager *Manager) Start(responseWriter http.ResponseWriter, request *http.Request) *Session {
-	manager.lock.Lock()
-	defer manager.lock.Unlock()
-
-	// Check if the client has a cookie with a session ID
-	cookie, err := request.Cookie(cookieName)
-	var sessionID string
-	if err != nil || cookie.Value == "" {
-		// Create a session ID to keep track of the session
-		sessionID = createSessionID()
-	} else {
-		sessionID, _ = url.QueryUnescape(cookie.Value)
-		// Use the client's session ID to get their session
-		// If session doesn't exist, drop through
-		if session, exists := manager.sessions[sessionID]; exists {
+	cookie, err := request.Cookie(manager.config.CookieName)
+	if err == nil && cookie.Value != "" {
+		sessionID, _ := url.QueryUnescape(cookie.Value)
+		if session, err := manager.readSession(sessionID); err == nil {
+			session.lock.Lock()
 			session.lastUsed = time.Now()
+			session.lock.Unlock()
 			return session
 		}
+		// Session doesn't exist (expired or unknown), drop through and create a new one.
 	}
 
-	// Create the new session
-	session := NewSession(sessionID)
-	manager.sessions[sessionID] = session
-	// Store the session in the client's cookies
-	http.SetCookie(responseWriter, &http.Cookie{
-		Name:     cookieName,
-		Value:    url.QueryEscape(sessionID),
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   int(lifespan / time.Second),
-	})
+	sessionID := createSessionID()
+	session, err := manager.provider.SessionInit(sessionID)
+	if err != nil {
+		log.Printf("session: failed to init session: %v", err)
+		return NewSession(sessionID)
+	}
+	manager.writeCookie(responseWriter, session.ID())
 	return session
 }
 
+// Save persists session's current data through the Manager's provider and
+// refreshes the client's cookie. Handlers must call Save after mutating a
+// session (via Set, SetFlash, Delete, ...) for any provider other than the
+// in-memory one: SessionRead on the file and cookie providers hands back a
+// detached copy decoded from storage, so without an explicit Save those
+// mutations never reach the next request.
+func (manager *Manager) Save(responseWriter http.ResponseWriter, session *Session) error {
+	saved, err := manager.provider.SessionSave(session)
+	if err != nil {
+		return err
+	}
+	manager.writeCookie(responseWriter, saved.ID())
+	return nil
+}
+
+// Regenerate issues a new session ID for session, migrating its existing, live
+// data across (including anything set on it since it was obtained from Start)
+// and rewriting the client's cookie. Handlers should call this immediately
+// after a login, logout, or role change, passing the same *Session they've
+// been working with, to defeat session fixation attacks where an attacker
+// sets a victim's session ID before they authenticate.
+//
+// If session is nil, Regenerate behaves exactly like Start: a fresh session
+// is created and its cookie written.
+func (manager *Manager) Regenerate(responseWriter http.ResponseWriter, request *http.Request, session *Session) *Session {
+	if session == nil {
+		return manager.Start(responseWriter, request)
+	}
+
+	newSessionID := createSessionID()
+
+	regenerated, err := manager.provider.SessionRegenerate(session, newSessionID)
+	if err != nil {
+		log.Printf("session: failed to regenerate session %q: %v", session.ID(), err)
+		return manager.Start(responseWriter, request)
+	}
+
+	manager.writeCookie(responseWriter, regenerated.ID())
+	return regenerated
+}
+
 // End is a method that ends the session associated with the current user. If there is no session,
 // do nothing.
 func (manager *Manager) End(responseWriter http.ResponseWriter, request *http.Request) {
-	cookie, err := request.Cookie(cookieName)
+	cookie, err := request.Cookie(manager.config.CookieName)
 
 	// Client has no session, ignore
 	if err != nil || cookie.Value == "" {
 		return
 	}
 
-	// Destroy the session and the cookie
-	manager.lock.Lock()
-	defer manager.lock.Unlock()
-	delete(manager.sessions, cookie.Value)
+	sessionID, _ := url.QueryUnescape(cookie.Value)
+	if validSessionID(sessionID) {
+		if err := manager.provider.SessionDestroy(sessionID); err != nil {
+			log.Printf("session: failed to destroy session %q: %v", sessionID, err)
+		}
+	}
+
 	http.SetCookie(responseWriter, &http.Cookie{
-		Name:     cookieName,
+		Name:     manager.config.CookieName,
 		Path:     "/",
-		HttpOnly: true,
+		HttpOnly: *manager.config.HttpOnly,
+		Secure:   *manager.config.Secure,
 		Expires:  time.Now(),
 		MaxAge:   -1,
 	})
 }
 
+// writeCookie stores sessionID in the client's cookies under the manager's configured name.
+func (manager *Manager) writeCookie(responseWriter http.ResponseWriter, sessionID string) {
+	http.SetCookie(responseWriter, &http.Cookie{
+		Name:     manager.config.CookieName,
+		Value:    url.QueryEscape(sessionID),
+		Path:     "/",
+		HttpOnly: *manager.config.HttpOnly,
+		Secure:   *manager.config.Secure,
+		MaxAge:   int(manager.config.Lifespan / time.Second),
+	})
+}
+
 // createSessionID gnerates a crytographically secure session ID
 func createSessionID() string {
 	token := make([]byte, 32)
@@ -109,19 +235,42 @@ func createSessionID() string {
 	return base64.URLEncoding.EncodeToString(token)
 }
 
-// cleanSessions is an asynchronous function to remove old sessions
-func cleanSessions() {
+// sessionIDPattern restricts a client-supplied session ID to the base64url
+// alphabet (plus '=' padding) that every built-in Provider's own IDs are
+// made of: createSessionID's random token and CookieStore's encoded blob
+// (its ID *is* the cookie value) are both base64.URLEncoding output.
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_=-]+$`)
+
+// maxSessionIDLength bounds how long a cookie value Manager will pass to a
+// provider, comfortably above createSessionID's output and the cookie
+// provider's maxCookieSize-capped blob.
+const maxSessionIDLength = 8192
+
+// validSessionID reports whether sid is shaped like a session ID this
+// package could plausibly have issued. A request's cookie value is
+// untrusted input that flows straight into a Provider's storage key - the
+// file provider joins it into a filesystem path, for instance - so Manager
+// rejects anything outside the expected shape before it ever reaches a
+// provider, rather than leaving every Provider to defend against a
+// malicious ID on its own.
+func validSessionID(sid string) bool {
+	return sid != "" && len(sid) <= maxSessionIDLength && sessionIDPattern.MatchString(sid)
+}
+
+// readSession looks up sid through the provider, first rejecting anything
+// that isn't shaped like a session ID this package could have issued.
+func (manager *Manager) readSession(sid string) (*Session, error) {
+	if !validSessionID(sid) {
+		return nil, fmt.Errorf("session: %q is not a valid session id", sid)
+	}
+	return manager.provider.SessionRead(sid)
+}
+
+// cleanSessions is an asynchronous method to remove old sessions
+func (manager *Manager) cleanSessions() {
 	for {
 		// Use a channel to sleep the thread until the time duration is complete
-		<-time.After(cleanFrequency)
-
-		sessionManager.lock.Lock()
-		// Iterate through the map and remove entries that have expired
-		for id, session := range sessionManager.sessions {
-			if time.Now().After(session.lastUsed.Add(lifespan)) {
-				delete(sessionManager.sessions, id)
-			}
-		}
-		sessionManager.lock.Unlock()
+		<-time.After(manager.config.GCFrequency)
+		manager.provider.SessionGC(manager.config.Lifespan)
 	}
 }
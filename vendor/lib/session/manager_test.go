@@ -0,0 +1,106 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewManagerDefaultsHttpOnlyAndSecureWhenUnset(t *testing.T) {
+	manager, err := NewManager("memory", &ManagerConfig{CookieName: "sid"})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	manager.Start(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	if !cookies[0].HttpOnly {
+		t.Error("a ManagerConfig that doesn't set HttpOnly should still default it to true, got false")
+	}
+	if cookies[0].Secure {
+		t.Error("a ManagerConfig that doesn't set Secure should still default it to false, got true")
+	}
+}
+
+func TestValidSessionID(t *testing.T) {
+	tests := []struct {
+		name string
+		sid  string
+		want bool
+	}{
+		{"created id", createSessionID(), true},
+		{"empty", "", false},
+		{"path traversal", "../../etc/passwd", false},
+		{"path separator", "a/b", false},
+		{"too long", strings.Repeat("a", maxSessionIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSessionID(tt.sid); got != tt.want {
+				t.Errorf("validSessionID(%q) = %v, want %v", tt.sid, got, tt.want)
+			}
+		})
+	}
+}
+
+// newFileManager builds a Manager directly (bypassing Register, which
+// panics on a duplicate name) over a fileProvider rooted at savePath.
+func newFileManager(savePath string) *Manager {
+	return &Manager{provider: NewFileProvider(savePath), config: defaultManagerConfig}
+}
+
+func TestStartRejectsPathTraversalSessionID(t *testing.T) {
+	base := t.TempDir()
+	savePath := filepath.Join(base, "sessions")
+	if err := os.Mkdir(savePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(base, "evil.sess")
+	if err := os.WriteFile(outside, []byte("not a session"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := newFileManager(savePath)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: manager.config.CookieName, Value: url.QueryEscape("../evil")})
+	rec := httptest.NewRecorder()
+
+	session := manager.Start(rec, req)
+	if session.ID() == "../evil" {
+		t.Fatal("Start() trusted a path-traversal session id instead of issuing a fresh one")
+	}
+}
+
+func TestEndRejectsPathTraversalSessionID(t *testing.T) {
+	base := t.TempDir()
+	savePath := filepath.Join(base, "sessions")
+	if err := os.Mkdir(savePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(base, "evil.sess")
+	if err := os.WriteFile(outside, []byte("not a session"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := newFileManager(savePath)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: manager.config.CookieName, Value: url.QueryEscape("../evil")})
+	rec := httptest.NewRecorder()
+
+	manager.End(rec, req)
+
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("End() deleted a file outside the session store via path traversal: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package session
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// shardCount is the number of independent shards the in-memory provider
+// splits its sessions across. Sized well above typical GOMAXPROCS so
+// unrelated requests rarely contend for the same shard.
+const shardCount = 32
+
+// sessionShard holds one slice of the full session set behind its own lock,
+// so a request touching one session never blocks a concurrent request
+// touching an unrelated one in a different shard.
+type sessionShard struct {
+	lock     sync.RWMutex
+	sessions map[string]*Session
+}
+
+// memoryProvider is the in-memory Provider: sessions live only for the
+// lifetime of the process, sharded across shardCount maps to avoid a single
+// global lock becoming a bottleneck.
+type memoryProvider struct {
+	shards [shardCount]*sessionShard
+}
+
+// NewMemoryProvider creates a Provider that keeps every session in process
+// memory. It is the default provider and matches the storage the package
+// used before pluggable providers were introduced.
+func NewMemoryProvider() Provider {
+	provider := &memoryProvider{}
+	for i := range provider.shards {
+		provider.shards[i] = &sessionShard{sessions: make(map[string]*Session)}
+	}
+	return provider
+}
+
+func init() {
+	Register("memory", NewMemoryProvider())
+}
+
+// shardIndex returns which shard sid belongs to, by FNV-1a hashing it.
+func shardIndex(sid string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(sid))
+	return h.Sum32() % shardCount
+}
+
+func (provider *memoryProvider) shardFor(sid string) *sessionShard {
+	return provider.shards[shardIndex(sid)]
+}
+
+func (provider *memoryProvider) SessionInit(sid string) (*Session, error) {
+	shard := provider.shardFor(sid)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	session := NewSession(sid)
+	shard.sessions[sid] = session
+	return session, nil
+}
+
+func (provider *memoryProvider) SessionRead(sid string) (*Session, error) {
+	shard := provider.shardFor(sid)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	session, exists := shard.sessions[sid]
+	if !exists {
+		return nil, fmt.Errorf("session: no session stored under id %q", sid)
+	}
+	return session, nil
+}
+
+// SessionSave is a no-op for the in-memory provider: SessionRead already
+// hands back the live *Session stored in its shard, so a handler's mutations
+// are visible to the next request without any extra step. It still
+// re-inserts session under its own ID, in case the caller is saving a
+// session whose ID it set itself rather than one obtained from this
+// provider.
+func (provider *memoryProvider) SessionSave(session *Session) (*Session, error) {
+	sid := session.ID()
+	shard := provider.shardFor(sid)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	shard.sessions[sid] = session
+	return session, nil
+}
+
+func (provider *memoryProvider) SessionExist(sid string) bool {
+	shard := provider.shardFor(sid)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	_, exists := shard.sessions[sid]
+	return exists
+}
+
+func (provider *memoryProvider) SessionDestroy(sid string) error {
+	shard := provider.shardFor(sid)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	delete(shard.sessions, sid)
+	return nil
+}
+
+func (provider *memoryProvider) SessionRegenerate(session *Session, newSid string) (*Session, error) {
+	oldSid := session.ID()
+	oldShard, newShard := provider.shardFor(oldSid), provider.shardFor(newSid)
+
+	// oldSid and newSid usually land in different shards. Always lock them in
+	// a fixed order (by index) so two concurrent regenerations can never
+	// deadlock by acquiring the same pair of shards in opposite order.
+	if oldShard == newShard {
+		oldShard.lock.Lock()
+		defer oldShard.lock.Unlock()
+	} else if shardIndex(oldSid) < shardIndex(newSid) {
+		oldShard.lock.Lock()
+		defer oldShard.lock.Unlock()
+		newShard.lock.Lock()
+		defer newShard.lock.Unlock()
+	} else {
+		newShard.lock.Lock()
+		defer newShard.lock.Unlock()
+		oldShard.lock.Lock()
+		defer oldShard.lock.Unlock()
+	}
+
+	delete(oldShard.sessions, oldSid)
+
+	// Hold session's own lock for the full swap, so a concurrent ID() or
+	// Get() on the same live *Session can never observe a half-updated id.
+	session.lock.Lock()
+	session.id = newSid
+	session.lastUsed = time.Now()
+	session.lock.Unlock()
+
+	newShard.sessions[newSid] = session
+	return session, nil
+}
+
+// SessionGC sweeps each shard in turn, releasing its lock before moving to
+// the next, so a long GC pass never blocks requests against unrelated shards
+// for more than the time it takes to sweep one of them.
+func (provider *memoryProvider) SessionGC(maxLifetime time.Duration) {
+	for _, shard := range provider.shards {
+		shard.lock.Lock()
+		for sid, session := range shard.sessions {
+			session.lock.RLock()
+			expired := time.Now().After(session.lastUsed.Add(maxLifetime))
+			session.lock.RUnlock()
+			if expired {
+				delete(shard.sessions, sid)
+			}
+		}
+		shard.lock.Unlock()
+	}
+}
+
+func (provider *memoryProvider) SessionAll() int {
+	total := 0
+	for _, shard := range provider.shards {
+		shard.lock.RLock()
+		total += len(shard.sessions)
+		shard.lock.RUnlock()
+	}
+	return total
+}
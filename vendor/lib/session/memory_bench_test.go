@@ -0,0 +1,35 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkMemoryProviderSessionReadParallel exercises SessionRead from many
+// goroutines across thousands of distinct sessions. Run with -cpu=1,4,8 to
+// see how the sharded map's throughput scales with GOMAXPROCS: reads against
+// different sessions land on different shards and do not serialize behind
+// one lock. Only the sharded provider is measured here.
+func BenchmarkMemoryProviderSessionReadParallel(b *testing.B) {
+	const sessionCount = 4096
+
+	provider := NewMemoryProvider()
+	ids := make([]string, sessionCount)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("bench-session-%d", i)
+		if _, err := provider.SessionInit(ids[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := provider.SessionRead(ids[i%sessionCount]); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+}
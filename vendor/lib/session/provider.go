@@ -0,0 +1,76 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Provider is implemented by session storage backends. A Manager delegates all
+// session persistence to a Provider so that handlers never deal with storage
+// details directly.
+type Provider interface {
+	// SessionInit creates and stores a brand new session under sid.
+	SessionInit(sid string) (*Session, error)
+	// SessionRead returns the session stored under sid, or an error if it does
+	// not exist.
+	SessionRead(sid string) (*Session, error)
+	// SessionExist reports whether a session is stored under sid.
+	SessionExist(sid string) bool
+	// SessionDestroy removes the session stored under sid.
+	SessionDestroy(sid string) error
+	// SessionSave persists session's current data back to the provider's
+	// backing store. Callers must use the returned Session afterwards: for
+	// providers that encode data into the ID itself (e.g. a cookie store),
+	// saving changes the ID, so the Manager can tell the client to pick up
+	// the new value. SessionSave must be called after any handler mutates a
+	// session's data - for anything other than the in-memory provider,
+	// mutations made through Session's own methods (Set, SetFlash, ...) are
+	// not visible to the next request until they are saved.
+	SessionSave(session *Session) (*Session, error)
+	// SessionRegenerate moves session to newSid, preserving whatever data it
+	// currently holds in memory rather than re-reading it from storage -
+	// otherwise any mutation a handler made since its last SessionRead or
+	// SessionSave would be silently dropped by the regeneration. A provider
+	// that encodes data into the ID itself (e.g. a cookie store) ignores
+	// newSid and assigns its own, so callers must use the returned Session's
+	// ID.
+	SessionRegenerate(session *Session, newSid string) (*Session, error)
+	// SessionGC removes sessions that have not been used within maxLifetime.
+	SessionGC(maxLifetime time.Duration)
+	// SessionAll returns the number of currently stored sessions, or -1 for
+	// a provider that keeps no server-side record of them (CookieStore).
+	SessionAll() int
+}
+
+var (
+	providersLock sync.RWMutex
+	providers     = make(map[string]Provider)
+)
+
+// Register makes a Provider available under name, so it can be selected with
+// NewManager. It panics if p is nil or if a provider is already registered
+// under the same name.
+func Register(name string, p Provider) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+
+	if p == nil {
+		panic("session: Register provider is nil")
+	}
+	if _, dup := providers[name]; dup {
+		panic(fmt.Sprintf("session: Register called twice for provider %q", name))
+	}
+	providers[name] = p
+}
+
+func provider(name string) (Provider, error) {
+	providersLock.RLock()
+	defer providersLock.RUnlock()
+
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("session: no provider registered under name %q", name)
+	}
+	return p, nil
+}
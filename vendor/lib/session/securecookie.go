@@ -0,0 +1,163 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxCookieSize is the largest encoded value the codec will produce or accept,
+// matching the ~4KB per-cookie limit enforced by browsers.
+const maxCookieSize = 4096
+
+// secureCookieRecord is the payload signed, compressed, encrypted and stored
+// entirely client-side.
+type secureCookieRecord struct {
+	Data     map[string]interface{}
+	Flashes  map[string]interface{}
+	LastUsed time.Time
+	IssuedAt int64 // unix seconds, validated against a Provider's configured lifespan on decode
+}
+
+// keyPair is one (hash key, block key) generation. encode always signs and
+// encrypts with keys[0]; decode tries every pair in order, which is what
+// makes key rotation possible: prepend a new pair and leave the old one in
+// place until every outstanding cookie has cycled through, then drop it.
+type keyPair struct {
+	hashKey  []byte
+	blockKey []byte
+}
+
+// secureCookieCodec turns session data into a signed, encrypted blob suitable
+// for a cookie value, and back. It has no notion of sessions or providers -
+// it only ever sees bytes in and bytes out.
+type secureCookieCodec struct {
+	keys []keyPair
+}
+
+func newSecureCookieCodec(hashKeys, blockKeys [][]byte) (*secureCookieCodec, error) {
+	if len(hashKeys) == 0 {
+		return nil, errors.New("session: at least one hash key is required")
+	}
+	if len(hashKeys) != len(blockKeys) {
+		return nil, errors.New("session: hashKeys and blockKeys must have the same length")
+	}
+	keys := make([]keyPair, len(hashKeys))
+	for i := range hashKeys {
+		if _, err := aes.NewCipher(blockKeys[i]); err != nil {
+			return nil, fmt.Errorf("session: block key %d: %w", i, err)
+		}
+		keys[i] = keyPair{hashKey: hashKeys[i], blockKey: blockKeys[i]}
+	}
+	return &secureCookieCodec{keys: keys}, nil
+}
+
+func (codec *secureCookieCodec) encode(record secureCookieRecord) (string, error) {
+	var plain bytes.Buffer
+	gz := gzip.NewWriter(&plain)
+	if err := gob.NewEncoder(gz).Encode(record); err != nil {
+		return "", fmt.Errorf("session: encoding cookie payload: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("session: compressing cookie payload: %w", err)
+	}
+
+	key := codec.keys[0]
+	block, err := aes.NewCipher(key.blockKey)
+	if err != nil {
+		return "", fmt.Errorf("session: creating cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("session: creating gcm: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("session: generating nonce: %w", err)
+	}
+	ciphertext := gcm.Seal(nonce, nonce, plain.Bytes(), nil)
+
+	mac := hmac.New(sha256.New, key.hashKey)
+	mac.Write(ciphertext)
+	signed := append(mac.Sum(nil), ciphertext...)
+
+	encoded := base64.URLEncoding.EncodeToString(signed)
+	if len(encoded) > maxCookieSize {
+		return "", fmt.Errorf("session: encoded cookie is %d bytes, exceeds %d byte limit", len(encoded), maxCookieSize)
+	}
+	return encoded, nil
+}
+
+// decode verifies and decrypts encoded against every registered key pair in
+// order, stopping at the first one that validates, then rejects the result
+// if its IssuedAt is older than maxAge. A zero maxAge skips the age check.
+func (codec *secureCookieCodec) decode(encoded string, maxAge time.Duration) (secureCookieRecord, error) {
+	var record secureCookieRecord
+
+	if len(encoded) > maxCookieSize {
+		return record, fmt.Errorf("session: encoded cookie is %d bytes, exceeds %d byte limit", len(encoded), maxCookieSize)
+	}
+	signed, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return record, fmt.Errorf("session: decoding cookie value: %w", err)
+	}
+	if len(signed) < sha256.Size {
+		return record, errors.New("session: cookie value too short")
+	}
+	gotMAC, ciphertext := signed[:sha256.Size], signed[sha256.Size:]
+
+	var plaintext []byte
+	for _, key := range codec.keys {
+		mac := hmac.New(sha256.New, key.hashKey)
+		mac.Write(ciphertext)
+		if !hmac.Equal(gotMAC, mac.Sum(nil)) {
+			continue
+		}
+
+		block, err := aes.NewCipher(key.blockKey)
+		if err != nil {
+			continue
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			continue
+		}
+		if len(ciphertext) < gcm.NonceSize() {
+			continue
+		}
+		nonce, body := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+		decrypted, err := gcm.Open(nil, nonce, body, nil)
+		if err != nil {
+			continue
+		}
+		plaintext = decrypted
+		break
+	}
+	if plaintext == nil {
+		return record, errors.New("session: cookie signature mismatch for every registered key")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return record, fmt.Errorf("session: decompressing cookie payload: %w", err)
+	}
+	defer gz.Close()
+	if err := gob.NewDecoder(gz).Decode(&record); err != nil {
+		return record, fmt.Errorf("session: decoding cookie payload: %w", err)
+	}
+
+	if maxAge > 0 && time.Now().After(time.Unix(record.IssuedAt, 0).Add(maxAge)) {
+		return record, errors.New("session: cookie has expired")
+	}
+	return record, nil
+}
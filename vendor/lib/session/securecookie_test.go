@@ -0,0 +1,154 @@
+package session
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// randomString returns n bytes of random data as a string, so that gzip
+// can't compress it away before the size check ever gets exercised.
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+func testKeys() ([][]byte, [][]byte) {
+	hashKey := make([]byte, 32)
+	blockKey := make([]byte, 32)
+	for i := range hashKey {
+		hashKey[i] = byte(i)
+	}
+	for i := range blockKey {
+		blockKey[i] = byte(i + 1)
+	}
+	return [][]byte{hashKey}, [][]byte{blockKey}
+}
+
+func TestSecureCookieCodecRoundTrip(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	codec, err := newSecureCookieCodec(hashKeys, blockKeys)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	now := time.Now()
+	record := secureCookieRecord{
+		Data:     map[string]interface{}{"user": "ada"},
+		Flashes:  map[string]interface{}{"notice": "welcome"},
+		LastUsed: now,
+		IssuedAt: now.Unix(),
+	}
+
+	encoded, err := codec.encode(record)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	decoded, err := codec.decode(encoded, 0)
+	if err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	if decoded.Data["user"] != "ada" {
+		t.Fatalf("decode() Data[user] = %v, want %q", decoded.Data["user"], "ada")
+	}
+	if decoded.Flashes["notice"] != "welcome" {
+		t.Fatalf("decode() Flashes[notice] = %v, want %q", decoded.Flashes["notice"], "welcome")
+	}
+}
+
+func TestSecureCookieCodecRejectsTampering(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	codec, err := newSecureCookieCodec(hashKeys, blockKeys)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	encoded, err := codec.encode(secureCookieRecord{IssuedAt: time.Now().Unix()})
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	tampered := []byte(encoded)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := codec.decode(string(tampered), 0); err == nil {
+		t.Fatal("decode() of a tampered cookie should fail")
+	}
+}
+
+func TestSecureCookieCodecKeyRotation(t *testing.T) {
+	oldHashKeys, oldBlockKeys := testKeys()
+	oldCodec, err := newSecureCookieCodec(oldHashKeys, oldBlockKeys)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	encoded, err := oldCodec.encode(secureCookieRecord{IssuedAt: time.Now().Unix()})
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	newHashKeys, newBlockKeys := testKeys()
+	newHashKeys[0][0] ^= 0xFF
+	newBlockKeys[0][0] ^= 0xFF
+
+	// A rotated codec tries every pair in order: the new pair first, then the
+	// still-valid old pair, so cookies signed before rotation keep decoding.
+	rotatedCodec, err := newSecureCookieCodec(
+		append(newHashKeys, oldHashKeys[0]),
+		append(newBlockKeys, oldBlockKeys[0]),
+	)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	if _, err := rotatedCodec.decode(encoded, 0); err != nil {
+		t.Fatalf("decode() with rotated keys error = %v, want success via the retained old pair", err)
+	}
+}
+
+func TestSecureCookieCodecRejectsOversizedCookie(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	codec, err := newSecureCookieCodec(hashKeys, blockKeys)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	huge := strings.Repeat("x", maxCookieSize+1)
+	if _, err := codec.decode(huge, 0); err == nil {
+		t.Fatal("decode() of an oversized cookie should fail")
+	}
+
+	record := secureCookieRecord{
+		Data:     map[string]interface{}{"blob": randomString(maxCookieSize * 2)},
+		IssuedAt: time.Now().Unix(),
+	}
+	if _, err := codec.encode(record); err == nil {
+		t.Fatal("encode() of an oversized payload should fail")
+	}
+}
+
+func TestSecureCookieCodecRejectsExpired(t *testing.T) {
+	hashKeys, blockKeys := testKeys()
+	codec, err := newSecureCookieCodec(hashKeys, blockKeys)
+	if err != nil {
+		t.Fatalf("newSecureCookieCodec() error = %v", err)
+	}
+
+	stale := time.Now().Add(-2 * time.Hour)
+	encoded, err := codec.encode(secureCookieRecord{IssuedAt: stale.Unix()})
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	if _, err := codec.decode(encoded, time.Hour); err == nil {
+		t.Fatal("decode() of an expired cookie should fail")
+	}
+	if _, err := codec.decode(encoded, 0); err != nil {
+		t.Fatalf("decode() with maxAge=0 should skip the expiry check, got error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Session holds the data associated with a single client across requests. A Session
+// is always owned by exactly one Provider, which is responsible for persisting it.
+type Session struct {
+	id        string
+	data      map[string]interface{}
+	immutable map[string]bool
+	flashes   map[string]interface{}
+	lastUsed  time.Time
+	lock      sync.RWMutex
+}
+
+// NewSession creates an empty session identified by id.
+func NewSession(id string) *Session {
+	return &Session{
+		id:        id,
+		data:      make(map[string]interface{}),
+		immutable: make(map[string]bool),
+		flashes:   make(map[string]interface{}),
+		lastUsed:  time.Now(),
+	}
+}
+
+// ID returns the session's unique identifier. A Provider may change this out
+// from under a concurrent reader (e.g. during SessionRegenerate), so ID reads
+// it under the same lock that guards the mutation.
+func (session *Session) ID() string {
+	session.lock.RLock()
+	defer session.lock.RUnlock()
+	return session.id
+}
+
+// Set stores a value in the session under key. A key previously set with
+// SetImmutable reverts to ordinary, aliased storage.
+func (session *Session) Set(key string, value interface{}) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.data[key] = value
+	delete(session.immutable, key)
+}
+
+// Get retrieves the value stored under key, or nil if it is not set. If key
+// was stored with SetImmutable, Get returns a fresh deep copy each time, so
+// the caller can never mutate the session's copy through what it gets back.
+func (session *Session) Get(key string) interface{} {
+	session.lock.RLock()
+	defer session.lock.RUnlock()
+	if session.immutable[key] {
+		return deepCopy(session.data[key])
+	}
+	return session.data[key]
+}
+
+// Delete removes the value stored under key.
+func (session *Session) Delete(key string) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	delete(session.data, key)
+	delete(session.immutable, key)
+}